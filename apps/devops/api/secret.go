@@ -0,0 +1,82 @@
+package api
+
+import (
+	"pandax/apps/devops/services/k8s/client"
+	"pandax/apps/devops/services/k8s/secret"
+	"pandax/kit/biz"
+	"pandax/kit/restfulx"
+)
+
+type SecretApi struct {
+}
+
+// InsertOpaqueSecret 创建Opaque类型Secret
+func (s *SecretApi) InsertOpaqueSecret(rc *restfulx.ReqCtx) {
+	var data secret.OpaqueSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// InsertTLSSecret 创建TLS类型Secret
+func (s *SecretApi) InsertTLSSecret(rc *restfulx.ReqCtx) {
+	var data secret.TLSSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// InsertDockerConfigJSONSecret 创建dockerconfigjson类型的镜像仓库Secret
+func (s *SecretApi) InsertDockerConfigJSONSecret(rc *restfulx.ReqCtx) {
+	var data secret.DockerConfigJSONSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// InsertSSHAuthSecret 创建SSH认证类型Secret
+func (s *SecretApi) InsertSSHAuthSecret(rc *restfulx.ReqCtx) {
+	var data secret.SSHAuthSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// InsertBasicAuthSecret 创建BasicAuth类型Secret
+func (s *SecretApi) InsertBasicAuthSecret(rc *restfulx.ReqCtx) {
+	var data secret.BasicAuthSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// InsertImagePullSecret 创建dockercfg类型的镜像仓库Secret
+func (s *SecretApi) InsertImagePullSecret(rc *restfulx.ReqCtx) {
+	var data secret.ImagePullSecretSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	rc.ResData = s.createSecret(rc, &data)
+}
+
+// GetSecretDetail 获取Secret详情（含按key进行Base64编码的内容）
+func (s *SecretApi) GetSecretDetail(rc *restfulx.ReqCtx) {
+	clientSet, err := client.GetClientSet(restfulx.PathParam(rc, "cluster"))
+	biz.ErrIsNil(err, "获取集群连接失败")
+	detail, err := secret.GetSecretDetail(clientSet, restfulx.PathParam(rc, "namespace"), restfulx.PathParam(rc, "name"))
+	biz.ErrIsNil(err, "查询Secret详情失败")
+	rc.ResData = detail
+}
+
+// UpdateSecret 更新Secret内容
+func (s *SecretApi) UpdateSecret(rc *restfulx.ReqCtx) {
+	var data secret.SecretUpdateSpec
+	restfulx.BindJsonAndValid(rc, &data)
+	clientSet, err := client.GetClientSet(restfulx.PathParam(rc, "cluster"))
+	biz.ErrIsNil(err, "获取集群连接失败")
+	detail, err := secret.UpdateSecret(clientSet, &data)
+	biz.ErrIsNil(err, "更新Secret失败")
+	rc.ResData = detail
+}
+
+// createSecret 按集群获取client并委托secret包完成创建，所有类型的Secret共用这一条路径
+func (s *SecretApi) createSecret(rc *restfulx.ReqCtx, spec secret.SecretSpec) *secret.Secret {
+	clientSet, err := client.GetClientSet(restfulx.PathParam(rc, "cluster"))
+	biz.ErrIsNil(err, "获取集群连接失败")
+	result, err := secret.CreateSecret(clientSet, spec)
+	biz.ErrIsNil(err, "创建Secret失败")
+	return result
+}
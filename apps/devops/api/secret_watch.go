@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"k8s.io/client-go/kubernetes"
+	"pandax/apps/devops/entity/k8s"
+	"pandax/apps/devops/services/k8s/client"
+	k8scommon "pandax/apps/devops/services/k8s/common"
+	"pandax/apps/devops/services/k8s/dataselect"
+	"pandax/apps/devops/services/k8s/rbac"
+	"pandax/apps/devops/services/k8s/secret"
+	"pandax/kit/biz"
+	"pandax/kit/restfulx"
+)
+
+// GetSecretList Secret列表数据，走informer缓存而非直接List到apiserver，并按RBAC过滤掉调用方不可见的条目
+func (s *SecretApi) GetSecretList(rc *restfulx.ReqCtx) {
+	cluster := restfulx.PathParam(rc, "cluster")
+	namespace := restfulx.QueryParam(rc, "namespace")
+
+	lister, err := client.GetSecretLister(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+	list, err := secret.GetSecretList(lister, k8scommon.NewNamespaceQuery([]string{namespace}), parseSecretDataSelectQuery(rc))
+	biz.ErrIsNil(err, "查询Secret列表失败")
+
+	authClient, err := client.GetClientSet(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+	identity := rbac.IdentityForRole(rc.LoginAccount.UserName, rc.LoginAccount.RoleId)
+	list, err = secret.FilterSecretListByAccess(authClient, identity, namespace, list)
+	biz.ErrIsNil(err, "校验Secret访问权限失败")
+
+	rc.ResData = list
+}
+
+// parseSecretDataSelectQuery builds a DataSelectQuery from the request's type, labelSelector,
+// namespaceRegex and sortBy query params, so the filter/sort comparators secretCell.GetProperty
+// exposes are actually reachable from the API instead of every call running unfiltered.
+func parseSecretDataSelectQuery(rc *restfulx.ReqCtx) *dataselect.DataSelectQuery {
+	var filterBy []string
+	if secretType := restfulx.QueryParam(rc, "type"); secretType != "" {
+		filterBy = append(filterBy, string(dataselect.TypeProperty), secretType)
+	}
+	if labelSelector := restfulx.QueryParam(rc, "labelSelector"); labelSelector != "" {
+		filterBy = append(filterBy, string(dataselect.LabelSelectorProperty), labelSelector)
+	}
+	if namespaceRegex := restfulx.QueryParam(rc, "namespaceRegex"); namespaceRegex != "" {
+		filterBy = append(filterBy, string(dataselect.NamespaceProperty), namespaceRegex)
+	}
+
+	var sortBy []string
+	if sortByParam := restfulx.QueryParam(rc, "sortBy"); sortByParam != "" {
+		sortBy = append(sortBy, sortByParam)
+	}
+
+	return dataselect.NewDataSelectQuery(dataselect.NoPagination, dataselect.NewSortQuery(sortBy), dataselect.NewFilterQuery(filterBy))
+}
+
+// DeleteSecret 删除单个Secret，删除前校验调用方对该Secret的RBAC权限
+func (s *SecretApi) DeleteSecret(rc *restfulx.ReqCtx) {
+	cluster := restfulx.PathParam(rc, "cluster")
+	clientSet, err := client.GetClientSet(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+
+	identity := rbac.IdentityForRole(rc.LoginAccount.UserName, rc.LoginAccount.RoleId)
+	err = secret.DeleteSecret(clientSet.(*kubernetes.Clientset), identity, restfulx.PathParam(rc, "namespace"), restfulx.PathParam(rc, "name"))
+	biz.ErrIsNil(err, "删除Secret失败")
+}
+
+// DeleteCollectionSecret 批量删除Secret，逐条校验调用方的RBAC权限
+func (s *SecretApi) DeleteCollectionSecret(rc *restfulx.ReqCtx) {
+	var data []k8s.SecretsData
+	restfulx.BindJsonAndValid(rc, &data)
+
+	cluster := restfulx.PathParam(rc, "cluster")
+	clientSet, err := client.GetClientSet(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+
+	identity := rbac.IdentityForRole(rc.LoginAccount.UserName, rc.LoginAccount.RoleId)
+	err = secret.DeleteCollectionSecret(clientSet.(*kubernetes.Clientset), identity, data)
+	biz.ErrIsNil(err, "批量删除Secret失败")
+}
+
+// WatchSecretList 订阅Secret变更事件（SSE），前端保持长连接持续接收add/update/delete
+func (s *SecretApi) WatchSecretList(rc *restfulx.ReqCtx) {
+	cluster := restfulx.PathParam(rc, "cluster")
+	namespace := restfulx.QueryParam(rc, "namespace")
+
+	factory, err := client.GetInformerFactory(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+	lister, err := client.GetSecretLister(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+
+	stopCh := rc.Context.Request.Context().Done()
+	events := make(chan secret.WatchEvent, 64)
+	err = secret.WatchSecrets(factory, lister, namespace, events, stopCh)
+	biz.ErrIsNil(err, "订阅Secret变更失败")
+
+	// This handler streams Server-Sent Events, so it writes the response itself instead
+	// of going through the usual rc.ResData JSON envelope.
+	w := rc.Context.Writer
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	flusher, _ := w.(http.Flusher)
+
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event := <-events:
+			payload, _ := json.Marshal(event)
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+	}
+}
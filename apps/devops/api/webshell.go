@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"pandax/apps/devops/services/k8s/client"
+	"pandax/apps/devops/services/k8s/rbac"
+	"pandax/apps/devops/services/k8s/webshell"
+	"pandax/base/global"
+	"pandax/kit/biz"
+	"pandax/kit/restfulx"
+)
+
+type WebShellApi struct {
+}
+
+// wsUpgrader upgrades the exec endpoint to a WebSocket. Origin checking is left to the
+// gateway in front of devops, matching how the rest of this package trusts rc.LoginAccount.
+var wsUpgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// GetWebShellToken 签发WebShell短期令牌，前端凭此令牌发起WebSocket升级，kubeconfig不会下发到浏览器
+// 签发前校验调用方对目标Pod是否拥有exec权限（create on pods/exec），避免任意devops用户越权进入任意Pod
+func (w *WebShellApi) GetWebShellToken(rc *restfulx.ReqCtx) {
+	cluster := restfulx.PathParam(rc, "cluster")
+	namespace := restfulx.QueryParam(rc, "namespace")
+	pod := restfulx.QueryParam(rc, "pod")
+
+	authClient, err := client.GetClientSet(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+
+	identity := rbac.IdentityForRole(rc.LoginAccount.UserName, rc.LoginAccount.RoleId)
+	err = webshell.CanExec(authClient, identity, namespace, pod)
+	biz.ErrIsNil(err, "无权限访问该Pod")
+
+	session := webshell.Session{
+		User:      rc.LoginAccount.UserName,
+		Cluster:   cluster,
+		Namespace: namespace,
+		Pod:       pod,
+		Container: restfulx.QueryParam(rc, "container"),
+	}
+	token, err := webshell.IssueToken(session)
+	biz.ErrIsNil(err, "签发WebShell令牌失败")
+	rc.ResData = map[string]string{"token": token}
+}
+
+// ExecWebShell 接受WebSocket升级，将浏览器终端流桥接到Pod的exec会话
+func (w *WebShellApi) ExecWebShell(rc *restfulx.ReqCtx) {
+	cluster := restfulx.PathParam(rc, "cluster")
+	token := restfulx.QueryParam(rc, "token")
+
+	session, err := webshell.ConsumeToken(token, cluster)
+	biz.ErrIsNil(err, "WebShell令牌校验失败")
+
+	clientSet, err := client.GetClientSet(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+	restConfig, err := client.GetRestConfig(cluster)
+	biz.ErrIsNil(err, "获取集群连接失败")
+
+	conn, err := wsUpgrader.Upgrade(rc.Context.Writer, rc.Context.Request, nil)
+	biz.ErrIsNil(err, "WebSocket升级失败")
+	defer conn.Close()
+
+	in := make(chan webshell.Stream)
+	out := make(chan webshell.Stream)
+	done := make(chan struct{})
+	var stopOnce sync.Once
+	stop := func() { stopOnce.Do(func() { close(done) }) }
+
+	go func() {
+		defer close(in)
+		for {
+			var frame webshell.Stream
+			if err := conn.ReadJSON(&frame); err != nil {
+				return
+			}
+			select {
+			case in <- frame:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	go func() {
+		for {
+			select {
+			case frame, ok := <-out:
+				if !ok {
+					return
+				}
+				if err := conn.WriteJSON(frame); err != nil {
+					// Unblock any frameWriter.Write stuck sending on out, otherwise
+					// Exec never returns and this session's goroutines leak forever.
+					stop()
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	if err := webshell.Exec(clientSet, restConfig, session, in, out, done); err != nil {
+		global.Log.Error(err.Error())
+	}
+	stop()
+	close(out)
+}
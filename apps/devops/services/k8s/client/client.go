@@ -0,0 +1,137 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/rest"
+	"pandax/apps/devops/services/k8s/rbac"
+)
+
+// defaultResyncPeriod controls how often the shared informers replay every cached object
+// to their event handlers as an update. The replay comes from the local cache; it is not
+// a re-list against the API server.
+const defaultResyncPeriod = 30 * time.Second
+
+// cacheSyncTimeout bounds how long GetInformerFactory waits for a cluster's informer
+// caches to sync, so an unreachable API server surfaces as an error instead of hanging
+// every lister/watch call on it forever.
+const cacheSyncTimeout = 30 * time.Second
+
+// clusterContext bundles a cluster's clientset with the shared informer factory built
+// on top of it.
+type clusterContext struct {
+	clientSet  kubernetes.Interface
+	restConfig *rest.Config
+	factory    informers.SharedInformerFactory
+	synced     chan struct{}
+	// stopCh stops factory's informers. It is closed when a later Register call
+	// replaces this cluster, so the old factory's goroutines don't leak past the
+	// point nothing references it anymore.
+	stopCh chan struct{}
+}
+
+// clusters caches one clusterContext per cluster name so handlers do not rebuild a
+// client (and renegotiate discovery) on every request.
+var clusters sync.Map
+
+// Register stores the clientset/rest.Config for cluster and starts a shared informer
+// factory over it so list-heavy resources (Secrets, ConfigMaps, ...) are served from a
+// local cache instead of hitting the API server on every request. restConfig is kept
+// alongside the clientset because SPDY upgrades (exec, port-forward) need it directly.
+// roleGroups, if non-nil, is merged into rbac's role-id -> Kubernetes Group impersonation
+// policy, so a cluster's RBAC-aware endpoints (e.g. secret listing/deletion) have a
+// policy to evaluate against as soon as the cluster is registered.
+func Register(cluster string, clientSet kubernetes.Interface, restConfig *rest.Config, roleGroups map[interface{}]string) {
+	if len(roleGroups) > 0 {
+		rbac.LoadPolicy(roleGroups)
+	}
+
+	factory := informers.NewSharedInformerFactory(clientSet, defaultResyncPeriod)
+	// Touching Informer() registers the type with the factory so Start below syncs it.
+	factory.Core().V1().Secrets().Informer()
+	factory.Core().V1().ConfigMaps().Informer()
+
+	stopCh := make(chan struct{})
+	ctx := &clusterContext{clientSet: clientSet, restConfig: restConfig, factory: factory, synced: make(chan struct{}), stopCh: stopCh}
+
+	if previous, loaded := clusters.Load(cluster); loaded {
+		// Re-registering (e.g. a kubeconfig reload) would otherwise leak the old
+		// factory's informer goroutines since nothing else ever stops them.
+		close(previous.(*clusterContext).stopCh)
+	}
+	clusters.Store(cluster, ctx)
+
+	factory.Start(stopCh)
+	go func() {
+		factory.WaitForCacheSync(stopCh)
+		close(ctx.synced)
+	}()
+}
+
+func lookup(cluster string) (*clusterContext, error) {
+	v, ok := clusters.Load(cluster)
+	if !ok {
+		return nil, fmt.Errorf("cluster %s is not registered", cluster)
+	}
+	return v.(*clusterContext), nil
+}
+
+// GetClientSet returns the cached clientset for cluster, or an error if the cluster
+// has not been registered (e.g. its kubeconfig was never loaded).
+func GetClientSet(cluster string) (kubernetes.Interface, error) {
+	ctx, err := lookup(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.clientSet, nil
+}
+
+// GetRestConfig returns the rest.Config used to build cluster's clientset, needed by
+// callers that upgrade the connection themselves (e.g. SPDY exec streams).
+func GetRestConfig(cluster string) (*rest.Config, error) {
+	ctx, err := lookup(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return ctx.restConfig, nil
+}
+
+// GetInformerFactory returns the shared informer factory for cluster, blocking until its
+// caches have synced so a caller never reads an empty or partially-populated lister. The
+// wait is bounded by cacheSyncTimeout so an unreachable API server returns an error
+// instead of hanging the caller forever.
+func GetInformerFactory(cluster string) (informers.SharedInformerFactory, error) {
+	ctx, err := lookup(cluster)
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case <-ctx.synced:
+		return ctx.factory, nil
+	case <-time.After(cacheSyncTimeout):
+		return nil, fmt.Errorf("cluster %s informer cache did not sync within %s", cluster, cacheSyncTimeout)
+	}
+}
+
+// GetSecretLister returns a cache-backed lister for Secrets in cluster.
+func GetSecretLister(cluster string) (corelisters.SecretLister, error) {
+	factory, err := GetInformerFactory(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return factory.Core().V1().Secrets().Lister(), nil
+}
+
+// GetConfigMapLister returns a cache-backed lister for ConfigMaps in cluster.
+func GetConfigMapLister(cluster string) (corelisters.ConfigMapLister, error) {
+	factory, err := GetInformerFactory(cluster)
+	if err != nil {
+		return nil, err
+	}
+	return factory.Core().V1().ConfigMaps().Lister(), nil
+}
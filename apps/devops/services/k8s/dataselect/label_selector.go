@@ -0,0 +1,30 @@
+package dataselect
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// StdLabelSelector is a ComparableValue backed by a resource's labels. The filter value
+// is parsed as a standard Kubernetes label selector (e.g. "app=foo,env!=prod") and tested
+// with Matches, so callers can express arbitrary selector expressions rather than a single
+// label value.
+type StdLabelSelector labels.Set
+
+// Compare is not meaningful for label sets; they are unordered, so this reports equal.
+func (l StdLabelSelector) Compare(otherV ComparableValue) int {
+	return 0
+}
+
+// Contains parses otherV as a label selector expression (e.g. "app=foo,env!=prod") and
+// reports whether l's labels match it.
+func (l StdLabelSelector) Contains(otherV ComparableValue) bool {
+	other, ok := otherV.(StdComparableString)
+	if !ok {
+		return false
+	}
+	selector, err := labels.Parse(string(other))
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(l))
+}
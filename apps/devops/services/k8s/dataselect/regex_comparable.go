@@ -0,0 +1,32 @@
+package dataselect
+
+import (
+	"regexp"
+	"strings"
+)
+
+// StdComparableRegex is a ComparableValue whose Contains treats the filter value as a
+// regular expression rather than doing plain substring containment. Used for properties
+// like Namespace, where callers are expected to filter with an expression such as "^prod-".
+type StdComparableRegex string
+
+// Compare orders two namespace values lexically, same as StdComparableString.
+func (r StdComparableRegex) Compare(otherV ComparableValue) int {
+	other := otherV.(StdComparableRegex)
+	return strings.Compare(string(r), string(other))
+}
+
+// Contains compiles otherV as a regular expression and reports whether it matches r. An
+// unparsable pattern is treated as no match rather than an error, consistent with how the
+// generic dataselect filter ignores comparator failures.
+func (r StdComparableRegex) Contains(otherV ComparableValue) bool {
+	pattern, ok := otherV.(StdComparableString)
+	if !ok {
+		return false
+	}
+	matched, err := regexp.MatchString(string(pattern), string(r))
+	if err != nil {
+		return false
+	}
+	return matched
+}
@@ -0,0 +1,12 @@
+package dataselect
+
+// TypeProperty and LabelSelectorProperty extend the generic comparators so the Secret
+// list can filter/sort on fields beyond name and creation time.
+const (
+	// TypeProperty lets callers filter/sort secrets by their v1.SecretType, e.g. type=kubernetes.io/tls.
+	TypeProperty PropertyName = "type"
+
+	// LabelSelectorProperty lets callers filter secrets by a Kubernetes label selector
+	// expression, e.g. app=foo,env!=prod, instead of a single label value.
+	LabelSelectorProperty PropertyName = "labelSelector"
+)
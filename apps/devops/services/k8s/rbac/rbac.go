@@ -0,0 +1,85 @@
+package rbac
+
+import (
+	"context"
+	"sync"
+
+	authorizationV1 "k8s.io/api/authorization/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Identity is the Kubernetes identity a pandax user is impersonated as when a
+// SubjectAccessReview is evaluated, so the decision reflects the caller's own
+// permissions rather than the shared service account's.
+type Identity struct {
+	User   string
+	Groups []string
+}
+
+// policy maps a pandax role id (the same value carried on rc.LoginAccount.RoleId) to the
+// Kubernetes Group it impersonates. Keys are stored as interface{} because RoleId is a
+// numeric id, not the human-readable role name.
+var policy sync.Map
+
+// LoadPolicy merges role id -> Kubernetes Group impersonation mappings into the policy,
+// e.g. {1: "pandax:tenant-admin"}. It is called from client.Register, so it takes effect
+// for every cluster that supplies a roleGroups mapping; entries not mentioned in a given
+// call are left untouched, so registering one cluster cannot wipe another's policy.
+func LoadPolicy(roleGroups map[interface{}]string) {
+	for role, group := range roleGroups {
+		policy.Store(role, group)
+	}
+}
+
+// IdentityForRole returns the impersonation identity for a pandax role id. A role with no
+// mapping impersonates the bare username with no extra group, so the review falls back to
+// whatever RBAC bindings exist for that user directly.
+func IdentityForRole(user string, roleId interface{}) Identity {
+	identity := Identity{User: user}
+	if group, ok := policy.Load(roleId); ok {
+		identity.Groups = []string{group.(string)}
+	}
+	return identity
+}
+
+// CanAccess issues a SubjectAccessReview impersonating identity and reports whether verb
+// is allowed on the secrets resource in namespace.
+func CanAccess(client kubernetes.Interface, identity Identity, namespace, verb string) (bool, error) {
+	return review(client, identity, namespace, verb, "secrets", "", "")
+}
+
+// CanAccessNamed is like CanAccess but scopes the review to a single resource name, which
+// matters when a binding restricts access via resourceNames rather than the whole namespace.
+func CanAccessNamed(client kubernetes.Interface, identity Identity, namespace, verb, name string) (bool, error) {
+	return review(client, identity, namespace, verb, "secrets", "", name)
+}
+
+// CanExecPod issues a SubjectAccessReview for create on pods/exec, the same permission
+// the kubelet's exec handler itself requires — i.e. whatever RBAC rule would let identity
+// run `kubectl exec` against the pod directly.
+func CanExecPod(client kubernetes.Interface, identity Identity, namespace, pod string) (bool, error) {
+	return review(client, identity, namespace, "create", "pods", "exec", pod)
+}
+
+func review(client kubernetes.Interface, identity Identity, namespace, verb, resource, subresource, name string) (bool, error) {
+	sar := &authorizationV1.SubjectAccessReview{
+		Spec: authorizationV1.SubjectAccessReviewSpec{
+			User:   identity.User,
+			Groups: identity.Groups,
+			ResourceAttributes: &authorizationV1.ResourceAttributes{
+				Namespace:   namespace,
+				Verb:        verb,
+				Resource:    resource,
+				Subresource: subresource,
+				Name:        name,
+			},
+		},
+	}
+
+	result, err := client.AuthorizationV1().SubjectAccessReviews().Create(context.TODO(), sar, metaV1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
@@ -0,0 +1,44 @@
+package secret
+
+import (
+	v1 "k8s.io/api/core/v1"
+	"pandax/apps/devops/services/k8s/dataselect"
+)
+
+// secretCell wraps a v1.Secret so it can be filtered and sorted through dataselect.
+type secretCell v1.Secret
+
+// GetProperty exposes the secret fields dataselect can filter/sort on: name, creation
+// time, namespace, type, and labels (matched via a selector expression).
+func (cell secretCell) GetProperty(name dataselect.PropertyName) dataselect.ComparableValue {
+	switch name {
+	case dataselect.NameProperty:
+		return dataselect.StdComparableString(cell.ObjectMeta.Name)
+	case dataselect.CreationTimestampProperty:
+		return dataselect.StdComparableTime(cell.ObjectMeta.CreationTimestamp.Time)
+	case dataselect.NamespaceProperty:
+		return dataselect.StdComparableRegex(cell.ObjectMeta.Namespace)
+	case dataselect.TypeProperty:
+		return dataselect.StdComparableString(string(cell.Type))
+	case dataselect.LabelSelectorProperty:
+		return dataselect.StdLabelSelector(cell.ObjectMeta.Labels)
+	default:
+		return nil
+	}
+}
+
+func toCells(std []v1.Secret) []dataselect.DataCell {
+	cells := make([]dataselect.DataCell, len(std))
+	for i := range std {
+		cells[i] = secretCell(std[i])
+	}
+	return cells
+}
+
+func fromCells(cells []dataselect.DataCell) []v1.Secret {
+	std := make([]v1.Secret, len(cells))
+	for i := range cells {
+		std[i] = v1.Secret(cells[i].(secretCell))
+	}
+	return std
+}
@@ -2,15 +2,23 @@ package secret
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"pandax/base/global"
 
 	v1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/util/retry"
 	"pandax/apps/devops/entity/k8s"
 	k8scommon "pandax/apps/devops/services/k8s/common"
 	"pandax/apps/devops/services/k8s/dataselect"
+	"pandax/apps/devops/services/k8s/rbac"
 )
 
 // SecretSpec is a common interface for the specification of different secrets.
@@ -19,6 +27,9 @@ type SecretSpec interface {
 	GetType() v1.SecretType
 	GetNamespace() string
 	GetData() map[string][]byte
+	// Validate checks that the spec carries everything required to build a valid secret,
+	// returning a descriptive error so callers can fail fast before hitting the API server.
+	Validate() error
 }
 
 // ImagePullSecretSpec is a specification of an image pull secret implements SecretSpec
@@ -50,6 +61,242 @@ func (spec *ImagePullSecretSpec) GetData() map[string][]byte {
 	return map[string][]byte{v1.DockerConfigKey: spec.Data}
 }
 
+// Validate checks that the .dockercfg payload was provided.
+func (spec *ImagePullSecretSpec) Validate() error {
+	if len(spec.Data) == 0 {
+		return errors.New("image pull secret requires .dockercfg data")
+	}
+	return nil
+}
+
+// OpaqueSecretSpec is a specification of a generic Opaque secret implements SecretSpec
+type OpaqueSecretSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Data holds the arbitrary key/value pairs carried by the secret.
+	Data map[string]string `json:"data"`
+}
+
+// GetName returns the name of the OpaqueSecret
+func (spec *OpaqueSecretSpec) GetName() string {
+	return spec.Name
+}
+
+// GetType returns the type of the OpaqueSecret, which is always v1.SecretTypeOpaque
+func (spec *OpaqueSecretSpec) GetType() v1.SecretType {
+	return v1.SecretTypeOpaque
+}
+
+// GetNamespace returns the namespace of the OpaqueSecret
+func (spec *OpaqueSecretSpec) GetNamespace() string {
+	return spec.Namespace
+}
+
+// GetData returns the data the secret carries
+func (spec *OpaqueSecretSpec) GetData() map[string][]byte {
+	data := make(map[string][]byte, len(spec.Data))
+	for key, value := range spec.Data {
+		data[key] = []byte(value)
+	}
+	return data
+}
+
+// Validate checks that at least one data entry was provided.
+func (spec *OpaqueSecretSpec) Validate() error {
+	if len(spec.Data) == 0 {
+		return errors.New("opaque secret requires at least one data entry")
+	}
+	return nil
+}
+
+// TLSSecretSpec is a specification of a TLS secret implements SecretSpec
+type TLSSecretSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// Cert is the PEM-encoded certificate, optionally followed by the chain.
+	Cert string `json:"cert"`
+	// Key is the PEM-encoded private key matching Cert.
+	Key string `json:"key"`
+}
+
+// GetName returns the name of the TLSSecret
+func (spec *TLSSecretSpec) GetName() string {
+	return spec.Name
+}
+
+// GetType returns the type of the TLSSecret, which is always v1.SecretTypeTLS
+func (spec *TLSSecretSpec) GetType() v1.SecretType {
+	return v1.SecretTypeTLS
+}
+
+// GetNamespace returns the namespace of the TLSSecret
+func (spec *TLSSecretSpec) GetNamespace() string {
+	return spec.Namespace
+}
+
+// GetData returns the data the secret carries, keyed as tls.crt/tls.key
+func (spec *TLSSecretSpec) GetData() map[string][]byte {
+	return map[string][]byte{
+		v1.TLSCertKey:       []byte(spec.Cert),
+		v1.TLSPrivateKeyKey: []byte(spec.Key),
+	}
+}
+
+// Validate parses Cert/Key as a matching X.509 key pair so a bad paste is rejected
+// before it ever reaches the API server.
+func (spec *TLSSecretSpec) Validate() error {
+	if _, err := tls.X509KeyPair([]byte(spec.Cert), []byte(spec.Key)); err != nil {
+		return fmt.Errorf("cert/key does not form a valid TLS key pair: %v", err)
+	}
+	return nil
+}
+
+// dockerConfigJSON mirrors the .dockerconfigjson payload expected by v1.SecretTypeDockerConfigJson.
+type dockerConfigJSON struct {
+	Auths map[string]dockerConfigEntry `json:"auths"`
+}
+
+type dockerConfigEntry struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email,omitempty"`
+	Auth     string `json:"auth"`
+}
+
+// DockerConfigJSONSecretSpec is a specification of the modern image pull secret format
+// implements SecretSpec
+type DockerConfigJSONSecretSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	Server   string `json:"server"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+// GetName returns the name of the DockerConfigJSONSecret
+func (spec *DockerConfigJSONSecretSpec) GetName() string {
+	return spec.Name
+}
+
+// GetType returns the type of the DockerConfigJSONSecret, which is always
+// v1.SecretTypeDockerConfigJson
+func (spec *DockerConfigJSONSecretSpec) GetType() v1.SecretType {
+	return v1.SecretTypeDockerConfigJson
+}
+
+// GetNamespace returns the namespace of the DockerConfigJSONSecret
+func (spec *DockerConfigJSONSecretSpec) GetNamespace() string {
+	return spec.Namespace
+}
+
+// GetData builds the .dockerconfigjson payload from the registry credentials
+func (spec *DockerConfigJSONSecretSpec) GetData() map[string][]byte {
+	auth := base64.StdEncoding.EncodeToString([]byte(spec.Username + ":" + spec.Password))
+	config := dockerConfigJSON{
+		Auths: map[string]dockerConfigEntry{
+			spec.Server: {
+				Username: spec.Username,
+				Password: spec.Password,
+				Email:    spec.Email,
+				Auth:     auth,
+			},
+		},
+	}
+	// The payload shape is fixed, so marshalling it cannot fail.
+	raw, _ := json.Marshal(config)
+	return map[string][]byte{v1.DockerConfigJsonKey: raw}
+}
+
+// Validate checks that the registry, username and password were provided.
+func (spec *DockerConfigJSONSecretSpec) Validate() error {
+	if spec.Server == "" || spec.Username == "" || spec.Password == "" {
+		return errors.New("dockerconfigjson secret requires server, username and password")
+	}
+	return nil
+}
+
+// SSHAuthSecretSpec is a specification of an SSH auth secret implements SecretSpec
+type SSHAuthSecretSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	// PrivateKey is the PEM-encoded SSH private key.
+	PrivateKey string `json:"privateKey"`
+}
+
+// GetName returns the name of the SSHAuthSecret
+func (spec *SSHAuthSecretSpec) GetName() string {
+	return spec.Name
+}
+
+// GetType returns the type of the SSHAuthSecret, which is always v1.SecretTypeSSHAuth
+func (spec *SSHAuthSecretSpec) GetType() v1.SecretType {
+	return v1.SecretTypeSSHAuth
+}
+
+// GetNamespace returns the namespace of the SSHAuthSecret
+func (spec *SSHAuthSecretSpec) GetNamespace() string {
+	return spec.Namespace
+}
+
+// GetData returns the data the secret carries, it is a single key-value pair
+func (spec *SSHAuthSecretSpec) GetData() map[string][]byte {
+	return map[string][]byte{v1.SSHAuthPrivateKey: []byte(spec.PrivateKey)}
+}
+
+// Validate checks that a private key was provided.
+func (spec *SSHAuthSecretSpec) Validate() error {
+	if spec.PrivateKey == "" {
+		return errors.New("ssh-auth secret requires a private key")
+	}
+	return nil
+}
+
+// BasicAuthSecretSpec is a specification of a basic auth secret implements SecretSpec
+type BasicAuthSecretSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// GetName returns the name of the BasicAuthSecret
+func (spec *BasicAuthSecretSpec) GetName() string {
+	return spec.Name
+}
+
+// GetType returns the type of the BasicAuthSecret, which is always v1.SecretTypeBasicAuth
+func (spec *BasicAuthSecretSpec) GetType() v1.SecretType {
+	return v1.SecretTypeBasicAuth
+}
+
+// GetNamespace returns the namespace of the BasicAuthSecret
+func (spec *BasicAuthSecretSpec) GetNamespace() string {
+	return spec.Namespace
+}
+
+// GetData returns the data the secret carries
+func (spec *BasicAuthSecretSpec) GetData() map[string][]byte {
+	return map[string][]byte{
+		v1.BasicAuthUsernameKey: []byte(spec.Username),
+		v1.BasicAuthPasswordKey: []byte(spec.Password),
+	}
+}
+
+// Validate checks that at least a username or a password was provided; either one alone
+// is a valid kubernetes.io/basic-auth secret.
+func (spec *BasicAuthSecretSpec) Validate() error {
+	if spec.Username == "" && spec.Password == "" {
+		return errors.New("basic-auth secret requires a username or a password")
+	}
+	return nil
+}
+
 // Secret is a single secret returned to the frontend.
 type Secret struct {
 	ObjectMeta k8s.ObjectMeta `json:"objectMeta"`
@@ -65,19 +312,83 @@ type SecretList struct {
 	Secrets []Secret `json:"secrets"`
 }
 
-// GetSecretList returns all secrets in the given namespace.
-func GetSecretList(client kubernetes.Interface, namespace *k8scommon.NamespaceQuery, dsQuery *dataselect.DataSelectQuery) (*SecretList, error) {
+// GetSecretList returns all secrets in the given namespace, served from the informer
+// cache (via lister) instead of a live List call against the API server.
+func GetSecretList(lister corelisters.SecretLister, namespace *k8scommon.NamespaceQuery, dsQuery *dataselect.DataSelectQuery) (*SecretList, error) {
 	global.Log.Info(fmt.Sprintf("Getting list of secrets in %s namespace", namespace))
-	secretList, err := client.CoreV1().Secrets(namespace.ToRequestParam()).List(context.TODO(), k8s.ListEverything)
+
+	var items []*v1.Secret
+	var err error
+	if ns := namespace.ToRequestParam(); ns == v1.NamespaceAll {
+		items, err = lister.List(labels.Everything())
+	} else {
+		items, err = lister.Secrets(ns).List(labels.Everything())
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	return ToSecretList(secretList.Items, dsQuery), nil
+	secrets := make([]v1.Secret, 0, len(items))
+	for _, item := range items {
+		secrets = append(secrets, *item)
+	}
+
+	return ToSecretList(secrets, dsQuery), nil
 }
 
-// CreateSecret creates a single secret using the cluster API client
+// FilterSecretListByAccess drops secrets identity is not authorized to see. It first
+// checks whether identity can list secrets across the whole namespace; only when that
+// coarse check fails does it fall back to reviewing each secret individually.
+//
+// The fallback costs one "list" SubjectAccessReview per distinct namespace present in
+// list (cached in listAllowed) plus one "get" review per secret that lives in a denied
+// namespace. A single-namespace query whose coarse check was denied therefore repeats
+// that namespace's "list" review once and then issues one "get" review per secret.
+func FilterSecretListByAccess(authClient kubernetes.Interface, identity rbac.Identity, namespace string, list *SecretList) (*SecretList, error) {
+	allowed, err := rbac.CanAccess(authClient, identity, namespace, "list")
+	if err != nil {
+		return nil, err
+	}
+	if allowed {
+		return list, nil
+	}
+
+	listAllowed := make(map[string]bool)
+	visible := make([]Secret, 0, len(list.Secrets))
+	for _, item := range list.Secrets {
+		ns := item.ObjectMeta.Namespace
+		ok, cached := listAllowed[ns]
+		if !cached {
+			ok, err = rbac.CanAccess(authClient, identity, ns, "list")
+			if err != nil {
+				return nil, err
+			}
+			listAllowed[ns] = ok
+		}
+		if !ok {
+			ok, err = rbac.CanAccessNamed(authClient, identity, ns, "get", item.ObjectMeta.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if ok {
+			visible = append(visible, item)
+		}
+	}
+
+	list.Secrets = visible
+	list.ListMeta = k8s.ListMeta{TotalItems: len(visible)}
+	return list, nil
+}
+
+// CreateSecret creates a single secret using the cluster API client. The spec is
+// validated first so a malformed kind (e.g. a TLS key pair that does not parse) fails
+// fast with a clear error instead of being rejected deep inside the apiserver.
 func CreateSecret(client kubernetes.Interface, spec SecretSpec) (*Secret, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+
 	namespace := spec.GetNamespace()
 	secret := &v1.Secret{
 		ObjectMeta: metaV1.ObjectMeta{
@@ -88,8 +399,11 @@ func CreateSecret(client kubernetes.Interface, spec SecretSpec) (*Secret, error)
 		Data: spec.GetData(),
 	}
 	_, err := client.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metaV1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
 	result := toSecret(secret)
-	return &result, err
+	return &result, nil
 }
 
 func toSecret(secret *v1.Secret) Secret {
@@ -100,6 +414,92 @@ func toSecret(secret *v1.Secret) Secret {
 	}
 }
 
+// SecretDetail is a single secret returned to the frontend together with its contents.
+type SecretDetail struct {
+	ObjectMeta k8s.ObjectMeta `json:"objectMeta"`
+	TypeMeta   k8s.TypeMeta   `json:"typeMeta"`
+	Type       v1.SecretType  `json:"type"`
+
+	// Data holds the secret's contents, base64-encoded per key for JSON transport.
+	Data map[string]string `json:"data"`
+}
+
+// GetSecretDetail returns the details of a single secret, including its contents
+// base64-encoded per key.
+func GetSecretDetail(client kubernetes.Interface, namespace, name string) (*SecretDetail, error) {
+	global.Log.Info(fmt.Sprintf("Getting details of %s secret in %s namespace", name, namespace))
+	rawSecret, err := client.CoreV1().Secrets(namespace).Get(context.TODO(), name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSecretDetail(rawSecret), nil
+}
+
+func toSecretDetail(secret *v1.Secret) *SecretDetail {
+	data := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		data[key] = base64.StdEncoding.EncodeToString(value)
+	}
+
+	return &SecretDetail{
+		ObjectMeta: k8s.NewObjectMeta(secret.ObjectMeta),
+		TypeMeta:   k8s.NewTypeMeta(k8s.ResourceKindSecret),
+		Type:       secret.Type,
+		Data:       data,
+	}
+}
+
+// SecretUpdateSpec describes the mutable fields of a secret update request. Data is
+// base64-encoded per key, matching the shape returned by GetSecretDetail.
+type SecretUpdateSpec struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+
+	Data        map[string]string `json:"data"`
+	Annotations map[string]string `json:"annotations"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// UpdateSecret applies spec to the named secret using an optimistic Update. On a
+// resourceVersion conflict it re-fetches the latest object and retries, so a caller does
+// not need to resolve the race itself.
+func UpdateSecret(client kubernetes.Interface, spec *SecretUpdateSpec) (*SecretDetail, error) {
+	data := make(map[string][]byte, len(spec.Data))
+	for key, value := range spec.Data {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("data[%s] is not valid base64: %v", key, err)
+		}
+		data[key] = decoded
+	}
+
+	var updated *v1.Secret
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current, getErr := client.CoreV1().Secrets(spec.Namespace).Get(context.TODO(), spec.Name, metaV1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+
+		current.Data = data
+		if spec.Annotations != nil {
+			current.Annotations = spec.Annotations
+		}
+		if spec.Labels != nil {
+			current.Labels = spec.Labels
+		}
+
+		var updateErr error
+		updated, updateErr = client.CoreV1().Secrets(spec.Namespace).Update(context.TODO(), current, metaV1.UpdateOptions{})
+		return updateErr
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return toSecretDetail(updated), nil
+}
+
 func ToSecretList(secrets []v1.Secret, dsQuery *dataselect.DataSelectQuery) *SecretList {
 	newSecretList := &SecretList{
 		ListMeta: k8s.ListMeta{TotalItems: len(secrets)},
@@ -117,7 +517,17 @@ func ToSecretList(secrets []v1.Secret, dsQuery *dataselect.DataSelectQuery) *Sec
 	return newSecretList
 }
 
-func DeleteSecret(client *kubernetes.Clientset, namespace string, name string) error {
+// DeleteSecret deletes the named secret after confirming identity is allowed to delete it,
+// so a caller with a working kubeconfig but no RBAC grant cannot remove secrets it can't see.
+func DeleteSecret(client *kubernetes.Clientset, identity rbac.Identity, namespace string, name string) error {
+	allowed, err := rbac.CanAccessNamed(client, identity, namespace, "delete", name)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("user %s is not authorized to delete secret %s/%s", identity.User, namespace, name)
+	}
+
 	global.Log.Info(fmt.Sprintf("请求删除Secret: %v, namespace: %v", name, namespace))
 	return client.CoreV1().Secrets(namespace).Delete(
 		context.TODO(),
@@ -126,11 +536,19 @@ func DeleteSecret(client *kubernetes.Clientset, namespace string, name string) e
 	)
 }
 
-func DeleteCollectionSecret(client *kubernetes.Clientset, secretList []k8s.SecretsData) (err error) {
+func DeleteCollectionSecret(client *kubernetes.Clientset, identity rbac.Identity, secretList []k8s.SecretsData) (err error) {
 	global.Log.Info("批量删除Secret开始")
 	for _, v := range secretList {
+		allowed, err := rbac.CanAccessNamed(client, identity, v.Namespace, "delete", v.Name)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return fmt.Errorf("user %s is not authorized to delete secret %s/%s", identity.User, v.Namespace, v.Name)
+		}
+
 		global.Log.Info(fmt.Sprintf("delete Secret：%v, ns: %v", v.Name, v.Namespace))
-		err := client.CoreV1().Secrets(v.Namespace).Delete(
+		err = client.CoreV1().Secrets(v.Namespace).Delete(
 			context.TODO(),
 			v.Name,
 			metaV1.DeleteOptions{},
@@ -0,0 +1,111 @@
+package secret
+
+import (
+	"fmt"
+
+	apiErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"pandax/apps/devops/entity/k8s"
+	"pandax/base/global"
+)
+
+// WatchEventType mirrors the kind of change a WatchEvent carries.
+type WatchEventType string
+
+const (
+	WatchEventAdded    WatchEventType = "ADDED"
+	WatchEventModified WatchEventType = "MODIFIED"
+	WatchEventDeleted  WatchEventType = "DELETED"
+)
+
+// WatchEvent is a single Secret change pushed to subscribers of WatchSecrets.
+type WatchEvent struct {
+	Type   WatchEventType `json:"type"`
+	Secret Secret         `json:"secret"`
+}
+
+// WatchSecrets registers event handlers on the Secrets informer and forwards add/update/
+// delete events to events as they happen. Changes are queued by object key rather than by
+// payload, so a burst of writes to the same secret collapses into a single re-read of its
+// latest state instead of flooding the browser with one event per write.
+func WatchSecrets(factory informers.SharedInformerFactory, lister corelisters.SecretLister, namespace string, events chan<- WatchEvent, stopCh <-chan struct{}) error {
+	informer := factory.Core().V1().Secrets().Informer()
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+
+	enqueue := func(obj interface{}) {
+		if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+			obj = tombstone.Obj
+		}
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			queue.Add(key)
+		}
+	}
+
+	registration, err := informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, newObj interface{}) { enqueue(newObj) },
+		DeleteFunc: enqueue,
+	})
+	if err != nil {
+		return err
+	}
+
+	// seen tracks which keys have already surfaced a MODIFIED/ADDED event, so the first
+	// sighting of a key is reported as ADDED and later ones as MODIFIED. It is only ever
+	// touched by the single worker goroutine below, so it needs no locking.
+	seen := make(map[string]struct{})
+
+	go func() {
+		for {
+			key, shutdown := queue.Get()
+			if shutdown {
+				return
+			}
+
+			keyStr := key.(string)
+			ns, name, splitErr := cache.SplitMetaNamespaceKey(keyStr)
+			if splitErr != nil || (namespace != "" && ns != namespace) {
+				queue.Done(key)
+				continue
+			}
+
+			var event WatchEvent
+			current, getErr := lister.Secrets(ns).Get(name)
+			switch {
+			case apiErrors.IsNotFound(getErr):
+				delete(seen, keyStr)
+				event = WatchEvent{Type: WatchEventDeleted, Secret: Secret{ObjectMeta: k8s.ObjectMeta{Name: name, Namespace: ns}}}
+			case getErr == nil:
+				eventType := WatchEventModified
+				if _, ok := seen[keyStr]; !ok {
+					eventType = WatchEventAdded
+					seen[keyStr] = struct{}{}
+				}
+				event = WatchEvent{Type: eventType, Secret: toSecret(current)}
+			default:
+				queue.Done(key)
+				continue
+			}
+
+			select {
+			case events <- event:
+			case <-stopCh:
+				queue.Done(key)
+				return
+			}
+			queue.Done(key)
+		}
+	}()
+
+	go func() {
+		<-stopCh
+		queue.ShutDown()
+		_ = informer.RemoveEventHandler(registration)
+	}()
+
+	global.Log.Info(fmt.Sprintf("Watching secrets in namespace %q for changes", namespace))
+	return nil
+}
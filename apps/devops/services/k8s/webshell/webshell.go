@@ -0,0 +1,213 @@
+package webshell
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+	"pandax/apps/devops/services/k8s/rbac"
+	"pandax/base/global"
+)
+
+// tokenTTL is how long a webshell session token stays valid before it must be reissued.
+// Short-lived, single-use tokens let the WebSocket upgrade authenticate without ever
+// shipping the kubeconfig to the browser.
+const tokenTTL = 30 * time.Second
+
+// Session identifies the user, cluster and pod/container a webshell token authorizes
+// access to. Cluster is bound in so a token minted against one cluster cannot be
+// replayed against a same-named pod in another cluster before it expires.
+type Session struct {
+	User      string
+	Cluster   string
+	Namespace string
+	Pod       string
+	Container string
+}
+
+type tokenEntry struct {
+	session Session
+	expires time.Time
+}
+
+// tokens caches issued tokens keyed by their random value. Entries are removed on first
+// use or once expired.
+var tokens sync.Map
+
+// IssueToken mints a short-TTL, single-use token authorizing session.
+func IssueToken(session Session) (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	now := time.Now()
+	tokens.Range(func(k, v interface{}) bool {
+		if now.After(v.(tokenEntry).expires) {
+			tokens.Delete(k)
+		}
+		return true
+	})
+	tokens.Store(token, tokenEntry{session: session, expires: time.Now().Add(tokenTTL)})
+	return token, nil
+}
+
+// ConsumeToken validates and deletes token, returning the Session it authorizes. Tokens
+// are single-use: once the WebSocket upgrade consumes one, it cannot be replayed. cluster
+// must match the one the token was issued for, so a token cannot be replayed against a
+// same-named pod in a different cluster.
+func ConsumeToken(token, cluster string) (Session, error) {
+	v, ok := tokens.LoadAndDelete(token)
+	if !ok {
+		return Session{}, fmt.Errorf("webshell token is invalid or already used")
+	}
+	entry := v.(tokenEntry)
+	if time.Now().After(entry.expires) {
+		return Session{}, fmt.Errorf("webshell token has expired")
+	}
+	if entry.session.Cluster != cluster {
+		return Session{}, fmt.Errorf("webshell token was not issued for cluster %s", cluster)
+	}
+	return entry.session, nil
+}
+
+// CanExec reports whether identity is authorized to open an exec session on the named
+// pod, via the same create-on-pods/exec SubjectAccessReview the kubelet itself enforces.
+func CanExec(authClient kubernetes.Interface, identity rbac.Identity, namespace, pod string) error {
+	allowed, err := rbac.CanExecPod(authClient, identity, namespace, pod)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return fmt.Errorf("user %s is not authorized to exec into pod %s/%s", identity.User, namespace, pod)
+	}
+	return nil
+}
+
+// TerminalSize is sent by the browser whenever its terminal viewport resizes.
+type TerminalSize struct {
+	Cols uint16 `json:"cols"`
+	Rows uint16 `json:"rows"`
+}
+
+// Stream is the JSON-tagged frame exchanged with the browser over the WebSocket: stdin/
+// stdout data and resize events share the same envelope so a single message type can be
+// multiplexed over one connection.
+type Stream struct {
+	Op   string        `json:"op"` // "stdin", "stdout" or "resize"
+	Data string        `json:"data,omitempty"`
+	Size *TerminalSize `json:"size,omitempty"`
+}
+
+// sizeQueue adapts resize frames into the remotecommand.TerminalSizeQueue the SPDY
+// executor polls to learn about browser resize events.
+type sizeQueue struct {
+	resizeCh chan TerminalSize
+}
+
+func (q *sizeQueue) Next() *remotecommand.TerminalSize {
+	size, ok := <-q.resizeCh
+	if !ok {
+		return nil
+	}
+	return &remotecommand.TerminalSize{Width: size.Cols, Height: size.Rows}
+}
+
+// frameReader turns inbound "stdin"/"resize" Stream frames into an io.Reader for
+// remotecommand, forwarding resize frames onto resizeCh as they arrive.
+type frameReader struct {
+	frames   <-chan Stream
+	resizeCh chan TerminalSize
+	buf      []byte
+}
+
+func (r *frameReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		frame, ok := <-r.frames
+		if !ok {
+			return 0, io.EOF
+		}
+		switch frame.Op {
+		case "resize":
+			if frame.Size != nil {
+				r.resizeCh <- *frame.Size
+			}
+		case "stdin":
+			r.buf = []byte(frame.Data)
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// frameWriter turns outbound exec stdout bytes into "stdout" Stream frames. Writes select
+// on done so a broken WebSocket on the consumer side (which closes done) unblocks the
+// exec stream instead of leaving it writing into a channel nobody drains anymore.
+type frameWriter struct {
+	out  chan<- Stream
+	done <-chan struct{}
+}
+
+func (w *frameWriter) Write(p []byte) (int, error) {
+	select {
+	case w.out <- Stream{Op: "stdout", Data: string(p)}:
+		return len(p), nil
+	case <-w.done:
+		return 0, io.EOF
+	}
+}
+
+// Exec bridges a browser terminal (represented as in/out Stream channels) to an
+// interactive exec session on session.Pod/session.Container, upgrading to SPDY the same
+// way kubectl exec does. done lets the caller unblock a write that is stuck because the
+// browser side has gone away. It blocks until the remote shell exits or the stream errors.
+func Exec(client kubernetes.Interface, restConfig *rest.Config, session Session, in <-chan Stream, out chan<- Stream, done <-chan struct{}) error {
+	req := client.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(session.Pod).
+		Namespace(session.Namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: session.Container,
+			Command:   []string{"/bin/sh"},
+			Stdin:     true,
+			Stdout:    true,
+			// A TTY session carries stderr combined into stdout; the kubelet exec
+			// handler rejects a request that sets both stderr and tty.
+			Stderr: false,
+			TTY:    true,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(restConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	resizeCh := make(chan TerminalSize)
+	reader := &frameReader{frames: in, resizeCh: resizeCh}
+	writer := &frameWriter{out: out, done: done}
+
+	global.Log.Info(fmt.Sprintf("opening webshell to %s/%s (container %s) for user %s", session.Namespace, session.Pod, session.Container, session.User))
+
+	err = executor.StreamWithContext(context.Background(), remotecommand.StreamOptions{
+		Stdin:             reader,
+		Stdout:            writer,
+		Tty:               true,
+		TerminalSizeQueue: &sizeQueue{resizeCh: resizeCh},
+	})
+	// The goroutine polling TerminalSizeQueue.Next can still be blocked on resizeCh after
+	// StreamWithContext returns; closing it makes Next return nil so that goroutine exits
+	// instead of leaking for the life of the process.
+	close(resizeCh)
+	return err
+}